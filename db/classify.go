@@ -0,0 +1,130 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"github.com/abcum/surreal/kvs"
+)
+
+// Classified is implemented by errors which can report a
+// stable, client-facing code, whether the failure is safe
+// to retry, and the HTTP status that best represents it.
+// This mirrors how pg/cockroach expose a SQLSTATE so that
+// drivers can loop on the 40001 (serialization failure)
+// class, rather than pattern-matching error strings.
+type Classified interface {
+	Code() string
+	Retryable() bool
+	HTTPStatus() int
+}
+
+// classify returns the Classified view of err, wrapping
+// errors from packages we don't own (such as kvs) in a
+// small adapter, and falling back to a generic, non-
+// retryable classification for anything else.
+func classify(err error) Classified {
+	switch e := err.(type) {
+	case nil:
+		return classifiedOK{}
+	case Classified:
+		return e
+	case *kvs.KVError:
+		return classifiedKVError{e}
+	case *kvs.DBError:
+		return classifiedDBError{e}
+	default:
+		return classifiedGeneric{e}
+	}
+}
+
+type classifiedOK struct{}
+
+func (classifiedOK) Code() string    { return "OK" }
+func (classifiedOK) Retryable() bool { return false }
+func (classifiedOK) HTTPStatus() int { return 200 }
+
+// classifiedKVError classifies a kvs.KVError, which arises
+// from MVCC write conflicts and concurrent transaction
+// aborts, and is therefore always safe to retry.
+type classifiedKVError struct{ err *kvs.KVError }
+
+func (c classifiedKVError) Code() string    { return "ERR_KV" }
+func (c classifiedKVError) Retryable() bool { return true }
+func (c classifiedKVError) HTTPStatus() int { return 409 }
+
+// classifiedDBError classifies a kvs.DBError, a terminal
+// failure of the underlying storage layer which is not
+// worth retrying.
+type classifiedDBError struct{ err *kvs.DBError }
+
+func (c classifiedDBError) Code() string    { return "ERR_DB" }
+func (c classifiedDBError) Retryable() bool { return false }
+func (c classifiedDBError) HTTPStatus() int { return 500 }
+
+// classifiedGeneric is the fallback classification for any
+// error which does not otherwise implement Classified.
+type classifiedGeneric struct{ err error }
+
+func (c classifiedGeneric) Code() string    { return "ERR" }
+func (c classifiedGeneric) Retryable() bool { return false }
+func (c classifiedGeneric) HTTPStatus() int { return 500 }
+
+func (c classifiedKVError) Error() string { return c.err.Error() }
+func (c classifiedDBError) Error() string { return c.err.Error() }
+func (c classifiedGeneric) Error() string { return c.err.Error() }
+
+// Code returns the client-visible error code for a
+// PermsError, for use by drivers that want to branch on
+// the failure class rather than a formatted message.
+func (e *PermsError) Code() string    { return "ERR_PE" }
+func (e *PermsError) Retryable() bool { return false }
+func (e *PermsError) HTTPStatus() int { return 403 }
+
+// Code returns the client-visible error code for an
+// ExistError.
+func (e *ExistError) Code() string    { return "ERR_EX" }
+func (e *ExistError) Retryable() bool { return false }
+func (e *ExistError) HTTPStatus() int { return 409 }
+
+// Code returns the client-visible error code for a
+// FieldError.
+func (e *FieldError) Code() string    { return "ERR_FD" }
+func (e *FieldError) Retryable() bool { return false }
+func (e *FieldError) HTTPStatus() int { return 422 }
+
+// Code returns the client-visible error code for an
+// IndexError.
+func (e *IndexError) Code() string    { return "ERR_IX" }
+func (e *IndexError) Retryable() bool { return false }
+func (e *IndexError) HTTPStatus() int { return 409 }
+
+// Code returns the client-visible error code for a
+// TimerError.
+func (e *TimerError) Code() string    { return "ERR_TO" }
+func (e *TimerError) Retryable() bool { return false }
+func (e *TimerError) HTTPStatus() int { return 504 }
+
+// Code returns the client-visible error code for a
+// ReadOnlyError.
+func (e *ReadOnlyError) Code() string    { return "ERR_RO" }
+func (e *ReadOnlyError) Retryable() bool { return false }
+func (e *ReadOnlyError) HTTPStatus() int { return 400 }
+
+// Code returns the client-visible error code for a
+// StaleSnapshotError. Retrying with the same timestamp can
+// never succeed, so it is not retryable.
+func (e *StaleSnapshotError) Code() string    { return "ERR_SS" }
+func (e *StaleSnapshotError) Retryable() bool { return false }
+func (e *StaleSnapshotError) HTTPStatus() int { return 400 }
@@ -0,0 +1,62 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassifyNil(t *testing.T) {
+
+	c := classify(nil)
+
+	if c.Code() != "OK" || c.Retryable() || c.HTTPStatus() != 200 {
+		t.Errorf("unexpected classification for nil: %+v", c)
+	}
+
+}
+
+func TestClassifyGeneric(t *testing.T) {
+
+	c := classify(errors.New("boom"))
+
+	if c.Code() != "ERR" {
+		t.Errorf("expected ERR, got %s", c.Code())
+	}
+
+	if c.Retryable() {
+		t.Error("a plain error should not be retryable")
+	}
+
+}
+
+func TestClassifyPassesThroughClassified(t *testing.T) {
+
+	c := classify(&ReadOnlyError{})
+
+	if c.Code() != "ERR_RO" {
+		t.Errorf("expected ERR_RO, got %s", c.Code())
+	}
+
+	if c.Retryable() {
+		t.Error("ReadOnlyError should not be retryable")
+	}
+
+	if c.HTTPStatus() != 400 {
+		t.Errorf("expected HTTP 400, got %d", c.HTTPStatus())
+	}
+
+}
@@ -15,18 +15,53 @@
 package db
 
 import (
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"context"
 
 	"runtime/debug"
 
-	"github.com/abcum/surreal/kvs"
+	"github.com/abcum/surreal/insights"
 	"github.com/abcum/surreal/log"
 	"github.com/abcum/surreal/mem"
 	"github.com/abcum/surreal/sql"
 )
 
+const (
+	retryInitialBackoff = 5 * time.Millisecond
+	retryMaxBackoff     = 80 * time.Millisecond
+	retryDefaultMax     = 5
+)
+
+// gcHorizon is how far back a BEGIN SNAPSHOT AT or MAX
+// STALENESS clause is allowed to pin a read-only transaction.
+// Older MVCC versions may already have been reclaimed by the
+// storage layer's garbage collector, so honouring an older
+// request would risk silently reading data that should have
+// been there but wasn't, rather than failing loudly.
+const gcHorizon = 5 * time.Minute
+
+// retryMax returns the configured maximum number of retry
+// attempts for opts, falling back to retryDefaultMax when
+// opts is nil or has not had OPTION RETRY set.
+func retryMax(opts *options) int {
+	if opts != nil && opts.retry > 0 {
+		return opts.retry
+	}
+	return retryDefaultMax
+}
+
+// nextBackoff doubles cur, capping it at retryMaxBackoff.
+func nextBackoff(cur time.Duration) time.Duration {
+	if cur *= 2; cur > retryMaxBackoff {
+		return retryMaxBackoff
+	}
+	return cur
+}
+
 type executor struct {
 	id    string
 	ns    string
@@ -37,6 +72,28 @@ type executor struct {
 	opts  *options
 	send  chan *Response
 	cache *cache
+	buf   []*Response
+	stms  []sql.Statement
+
+	// txStart, errStm, errErr and wait track the current
+	// transaction for the benefit of the insights
+	// subsystem, and are reset on each BEGIN. errErr is
+	// the error that errStm failed with, kept separately
+	// because a transaction that fails mid-way can still
+	// go on to commit or cancel cleanly, at which point
+	// the commit/cancel error alone no longer reflects
+	// what actually went wrong.
+	txStart time.Time
+	errStm  sql.Statement
+	errErr  error
+	wait    time.Duration
+
+	// ro and ts mark the current transaction as a
+	// read-only, or historical snapshot, transaction
+	// pinned at ts (a MVCC version time), and are reset
+	// on each BEGIN.
+	ro bool
+	ts int64
 }
 
 func newExecutor(id, ns, db string) (e *executor) {
@@ -55,6 +112,19 @@ func newExecutor(id, ns, db string) (e *executor) {
 
 	e.cache = new(cache)
 
+	e.ro = false
+	e.ts = 0
+
+	// A pooled executor may carry insights bookkeeping left
+	// over from a previous connection's last transaction;
+	// reset it so an implicit statement run before the first
+	// BEGIN never reports stale contention.
+
+	e.txStart = time.Time{}
+	e.errStm = nil
+	e.errErr = nil
+	e.wait = 0
+
 	return
 
 }
@@ -90,53 +160,244 @@ func (e *executor) execute(ctx context.Context, ast *sql.Query) {
 
 	defer func() {
 		if err := recover(); err != nil {
+
+			stack := debug.Stack()
+
 			log.WithPrefix(logKeyDB).WithFields(map[string]interface{}{
-				logKeyId: e.id, logKeyStack: string(debug.Stack()),
+				logKeyId: e.id, logKeyStack: string(stack),
 			}).Errorln(err)
+
+			start := e.txStart
+			if start.IsZero() {
+				start = time.Now()
+			}
+
+			insights.Default.Record(insights.Record{
+				ID:         e.id,
+				NS:         e.ns,
+				DB:         e.db,
+				Start:      start,
+				End:        time.Now(),
+				Statements: len(e.stms),
+				Status:     "ERR",
+				Detail:     fmt.Sprint(err),
+				Contention: e.wait,
+				Stack:      string(stack),
+			})
+
 		}
 	}()
 
 	// Loop over the defined query statements and
 	// process them, while listening for the quit
 	// channel to see if the client has gone away.
+	//
+	// When the query is marked PARALLEL, consecutive
+	// read-only statements are grouped together and
+	// dispatched concurrently instead of one at a time.
 
-	for _, stm := range ast.Statements {
+	for _, grp := range groupParallel(ast.Statements, ast.Parallel) {
 		select {
 		case <-ctx.Done():
 			return
 		default:
-			e.conduct(ctx, stm)
+			if len(grp) > 1 {
+				e.conductParallel(ctx, grp)
+			} else {
+				for _, stm := range grp {
+					e.conduct(ctx, stm)
+				}
+			}
 		}
 	}
 
 }
 
-func (e *executor) conduct(ctx context.Context, stm sql.Statement) {
+// groupParallel splits stms into runs that can be safely
+// dispatched together. When parallel is false every
+// statement gets its own single-item run. Otherwise,
+// consecutive non-transaction-control, read-only
+// statements are grouped into a single run, while
+// BEGIN/CANCEL/COMMIT and RETURN force a boundary so that
+// the existing ReturnStatement and transaction-control
+// semantics are unaffected.
+func groupParallel(stms []sql.Statement, parallel bool) [][]sql.Statement {
+
+	var groups [][]sql.Statement
+	var run []sql.Statement
+
+	flush := func() {
+		if len(run) > 0 {
+			groups = append(groups, run)
+			run = nil
+		}
+	}
 
-	var err error
-	var now time.Time
-	var rsp *Response
-	var buf []*Response
-	var res []interface{}
+	for _, stm := range stms {
 
-	// When in debugging mode, log every sql
-	// query, along with the query execution
-	// speed, so we can analyse slow queries.
+		if !parallel {
+			groups = append(groups, []sql.Statement{stm})
+			continue
+		}
 
-	log := log.WithPrefix(logKeySql).WithFields(map[string]interface{}{
-		logKeyId:   e.id,
-		logKeyKind: ctx.Value(ctxKeyKind),
-		logKeyVars: ctx.Value(ctxKeyVars),
-	})
+		switch stm.(type) {
+		case *sql.BeginStatement, *sql.CancelStatement, *sql.CommitStatement, *sql.ReturnStatement:
+			flush()
+			groups = append(groups, []sql.Statement{stm})
+			continue
+		}
+
+		if wr, ok := stm.(sql.WriteableStatement); ok && wr.Writeable() {
+			flush()
+			groups = append(groups, []sql.Statement{stm})
+			continue
+		}
+
+		run = append(run, stm)
+
+	}
+
+	flush()
+
+	return groups
+
+}
+
+// parallelPanic carries a recovered panic value across
+// from a parallel worker goroutine to the caller, so that
+// it can be re-raised there and handled by the existing
+// top-level recover in execute.
+type parallelPanic struct {
+	val   interface{}
+	stack []byte
+}
+
+// conductParallel runs a run of non-conflicting, read-only
+// statements concurrently, modelled on Vitess's
+// multiGoTransaction: each statement gets its own goroutine.
+// Dispatching a statement still reads and writes fields on
+// the shared executor - e.time (the version time every
+// subquery in the statement must agree on), e.wait and
+// e.errStm - so mu serialises the actual operate/
+// executeRetryable call, exactly as the shared e.dbo.TX is
+// protected elsewhere. Statements still each get their own
+// goroutine and may overlap around that critical section
+// (result marshalling, insight recording), which is as far
+// as they can safely run in parallel while sharing one
+// executor.
+func (e *executor) conductParallel(ctx context.Context, stms []sql.Statement) {
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var pv atomic.Value
+
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tx := e.dbo.TX != nil
+
+	out := make([]*Response, len(stms))
+
+	for i, stm := range stms {
+
+		wg.Add(1)
+
+		go func(i int, stm sql.Statement) {
+
+			defer wg.Done()
+
+			defer func() {
+				if r := recover(); r != nil {
+					pv.Store(parallelPanic{val: r, stack: debug.Stack()})
+				}
+			}()
+
+			now := time.Now()
+
+			var res []interface{}
+			var retries int
+			var err error
+
+			func() {
+				mu.Lock()
+				defer mu.Unlock()
+				if tx {
+					// Inside an explicit transaction every
+					// statement shares the one open snapshot.
+					res, err = e.operate(cctx, stm)
+				} else {
+					// Outside a transaction each statement is
+					// its own local, retryable read.
+					res, retries, err = e.executeRetryable(cctx, stm)
+				}
+				if err != nil && e.errStm == nil {
+					e.errStm, e.errErr = stm, err
+				}
+			}()
+
+			if err != nil {
+				cancel()
+			}
+
+			c := classify(err)
+
+			out[i] = &Response{
+				Time:      time.Since(now).String(),
+				Status:    c.Code(),
+				Code:      c.Code(),
+				Retryable: c.Retryable(),
+				Detail:    detail(err),
+				Result:    append([]interface{}{}, res...),
+				Retries:   retries,
+			}
+
+			if !tx {
+				insights.Default.Record(insights.Record{
+					ID:         e.id,
+					NS:         e.ns,
+					DB:         e.db,
+					Start:      now,
+					End:        time.Now(),
+					Statements: 1,
+					Statement:  fmt.Sprintf("%v", stm),
+					Status:     out[i].Status,
+					Detail:     out[i].Detail,
+					Retries:    retries,
+				})
+			}
+
+		}(i, stm)
 
-	if len(e.ns) != 0 {
-		log = log.WithField(logKeyNS, e.ns)
 	}
 
-	if len(e.db) != 0 {
-		log = log.WithField(logKeyDB, e.db)
+	wg.Wait()
+
+	// If any worker panicked, re-panic on this, the
+	// caller's goroutine, so that the existing top-level
+	// recover in execute still logs and records it.
+
+	if v := pv.Load(); v != nil {
+		panic(v.(parallelPanic).val)
 	}
 
+	if tx {
+		e.stms = append(e.stms, stms...)
+		e.buf = append(e.buf, out...)
+	} else {
+		for _, rsp := range out {
+			e.send <- rsp
+		}
+	}
+
+}
+
+func (e *executor) conduct(ctx context.Context, stm sql.Statement) {
+
+	var err error
+	var now time.Time
+	var rsp *Response
+	var res []interface{}
+
 	// If we are not inside a global transaction
 	// then reset the error to nil so that the
 	// next statement is not ignored.
@@ -149,13 +410,30 @@ func (e *executor) conduct(ctx context.Context, stm sql.Statement) {
 	// a TRANSACTION statement, and if it is
 	// then deal with it and move on to the next.
 
-	switch stm.(type) {
+	switch stm := stm.(type) {
 	case *sql.BeginStatement:
 		e.lock = new(mutex)
-		err = e.begin(ctx, true)
+		e.buf = nil
+		e.stms = nil
+		e.errStm = nil
+		e.errErr = nil
+		e.wait = 0
+		e.txStart = time.Now()
+		if err = e.beginTX(ctx, stm); err != nil {
+			c := classify(err)
+			e.send <- &Response{
+				Time:      time.Since(now).String(),
+				Status:    c.Code(),
+				Code:      c.Code(),
+				Retryable: c.Retryable(),
+				Detail:    detail(err),
+				Result:    []interface{}{},
+			}
+		}
 		return
 	case *sql.CancelStatement:
-		err, buf = e.cancel(buf, err, e.send)
+		err, e.buf = e.cancel(e.buf, err, e.send)
+		e.ro, e.ts = false, 0
 		if err != nil {
 			clear(e.id)
 		} else {
@@ -163,7 +441,8 @@ func (e *executor) conduct(ctx context.Context, stm sql.Statement) {
 		}
 		return
 	case *sql.CommitStatement:
-		err, buf = e.commit(buf, err, e.send)
+		err, e.buf = e.commit(ctx, e.buf, err, e.send)
+		e.ro, e.ts = false, 0
 		if err != nil {
 			clear(e.id)
 		} else {
@@ -172,37 +451,74 @@ func (e *executor) conduct(ctx context.Context, stm sql.Statement) {
 		return
 	}
 
+	// If we are inside a global transaction then
+	// snapshot the statement so that it can be
+	// replayed if the transaction is retried.
+
+	if e.dbo.TX != nil {
+		e.stms = append(e.stms, stm)
+	}
+
 	// If an error has occured and we are inside
 	// a global transaction, then ignore all
 	// subsequent statements in the transaction.
 
+	var retries int
+
 	if err == nil {
-		res, err = e.operate(ctx, stm)
+		if e.dbo.TX == nil {
+			// Local (implicit) transactions are retried
+			// in place, as there is only ever the one
+			// statement to re-run on conflict.
+			res, retries, err = e.executeRetryable(ctx, stm)
+		} else {
+			res, err = e.operate(ctx, stm)
+		}
 	} else {
 		res, err = []interface{}{}, errQueryNotExecuted
 	}
 
+	class := classify(err)
+
 	rsp = &Response{
-		Time:   time.Since(now).String(),
-		Status: status(err),
-		Detail: detail(err),
-		Result: append([]interface{}{}, res...),
+		Time:      time.Since(now).String(),
+		Status:    class.Code(),
+		Code:      class.Code(),
+		Retryable: class.Retryable(),
+		Detail:    detail(err),
+		Result:    append([]interface{}{}, res...),
+		Retries:   retries,
 	}
 
-	// Log the sql statement along with the
-	// query duration time, and mark it as
-	// an error if the query failed.
+	// Remember the first statement in the transaction
+	// that failed, so it can be reported by the insights
+	// subsystem once the transaction is committed or
+	// cancelled.
 
-	switch err.(type) {
-	default:
-		log.WithFields(map[string]interface{}{
-			logKeyTime: time.Since(now).String(),
-		}).Debugln(stm)
-	case error:
-		log.WithFields(map[string]interface{}{
-			logKeyTime:  time.Since(now).String(),
-			logKeyError: detail(err),
-		}).Errorln(stm)
+	if e.dbo.TX != nil && err != nil && e.errStm == nil {
+		e.errStm, e.errErr = stm, err
+	}
+
+	// Local (implicit) transactions are complete as soon
+	// as this single statement is done, so record their
+	// insight here rather than waiting for a COMMIT that
+	// will never come.
+
+	if e.dbo.TX == nil {
+		insights.Default.Record(insights.Record{
+			ID:         e.id,
+			NS:         e.ns,
+			DB:         e.db,
+			Start:      now,
+			End:        time.Now(),
+			Statements: 1,
+			Statement:  fmt.Sprintf("%v", stm),
+			Status:     rsp.Status,
+			Detail:     rsp.Detail,
+			Contention: e.wait,
+			Retries:    retries,
+		})
+		e.wait = 0
 	}
 
 	// If we are not inside a global transaction
@@ -220,9 +536,9 @@ func (e *executor) conduct(ctx context.Context, stm sql.Statement) {
 	if e.dbo.TX != nil {
 		switch stm.(type) {
 		case *sql.ReturnStatement:
-			buf = groupd(buf, rsp)
+			e.buf = groupd(e.buf, rsp)
 		default:
-			buf = append(buf, rsp)
+			e.buf = append(e.buf, rsp)
 		}
 	}
 
@@ -282,13 +598,105 @@ func (e *executor) operate(ctx context.Context, stm sql.Statement) (res []interf
 
 	// Specify a new time for the current executor
 	// iteration, so that all subqueries and async
-	// events are saved with the same version time.
+	// events are saved with the same version time. A
+	// read-only snapshot transaction instead keeps the
+	// time it was pinned to, so every subquery sees the
+	// same consistent, historical snapshot.
+
+	if e.ro && e.ts != 0 {
+		e.time = e.ts
+	} else {
+		e.time = time.Now().UnixNano()
+	}
+
+	// Reject any write statement running inside a
+	// read-only or historical snapshot transaction.
 
-	e.time = time.Now().UnixNano()
+	if e.ro {
+		if w, ok := stm.(sql.WriteableStatement); ok && w.Writeable() {
+			err = &ReadOnlyError{stm: stm}
+			return
+		}
+	}
 
 	// Execute the defined statement, receiving the
-	// result set, and any errors which occured
-	// while processing the query.
+	// result set, and any errors which occured while
+	// processing the query. This is run through the
+	// middleware chain registered via Use, so that
+	// cross-cutting behaviour such as auditing, rate
+	// limiting or tracing can wrap every statement
+	// without touching the dispatch type switch itself.
+
+	res, err = chain(e.logging(e.dispatch))(ctx, stm)
+
+	// If the context is already closed or failed,
+	// then ignore this result, clear all queued
+	// changes, and reset the transaction.
+
+	select {
+
+	case <-ctx.Done():
+
+		e.dbo.Cancel()
+		e.dbo.Reset()
+		clear(e.id)
+
+	default:
+
+		// If this is a local transaction for only the
+		// current statement, then commit or cancel
+		// depending on the result error.
+
+		if loc && e.dbo.Closed() == false {
+
+			// As this is a local transaction then
+			// make sure we reset the transaction
+			// context.
+
+			defer e.dbo.Reset()
+
+			// If there was an error with the query
+			// then clear the queued changes and
+			// return immediately.
+
+			if err != nil {
+				e.dbo.Cancel()
+				clear(e.id)
+				return
+			}
+
+			// Otherwise check if this is a read or
+			// a write transaction, and attempt to
+			// Cancel or Commit, returning any errors.
+
+			if !trw {
+				if err = e.dbo.Cancel(); err != nil {
+					clear(e.id)
+				} else {
+					clear(e.id)
+				}
+			} else {
+				if err = e.dbo.Commit(); err != nil {
+					clear(e.id)
+				} else {
+					flush(e.id)
+				}
+			}
+
+		}
+
+	}
+
+	return
+
+}
+
+// dispatch is the innermost StatementHandler: it holds
+// the type switch that routes a statement to the method
+// which actually executes it. All middleware registered
+// via Use, plus the built-in logging middleware, wrap
+// around this.
+func (e *executor) dispatch(ctx context.Context, stm sql.Statement) (res []interface{}, err error) {
 
 	switch stm := stm.(type) {
 
@@ -303,6 +711,8 @@ func (e *executor) operate(ctx context.Context, stm sql.Statement) (res []interf
 
 	case *sql.InfoStatement:
 		res, err = e.executeInfo(ctx, stm)
+	case *sql.InfoForInsightsStatement:
+		res, err = e.executeInfoForInsights(ctx, stm)
 
 	case *sql.LetStatement:
 		res, err = e.executeLet(ctx, stm)
@@ -379,72 +789,120 @@ func (e *executor) operate(ctx context.Context, stm sql.Statement) (res []interf
 
 	}
 
-	// If the context is already closed or failed,
-	// then ignore this result, clear all queued
-	// changes, and reset the transaction.
+	return
 
-	select {
+}
 
-	case <-ctx.Done():
+// logging is the always-on, per-executor Middleware which
+// replaces the ad-hoc debug/error logging that used to
+// live inline in conduct: it times the call to next, and
+// logs the statement along with its duration and outcome.
+func (e *executor) logging(next StatementHandler) StatementHandler {
+	return func(ctx context.Context, stm sql.Statement) ([]interface{}, error) {
 
-		e.dbo.Cancel()
-		e.dbo.Reset()
-		clear(e.id)
+		now := time.Now()
 
-	default:
+		res, err := next(ctx, stm)
 
-		// If this is a local transaction for only the
-		// current statement, then commit or cancel
-		// depending on the result error.
+		l := log.WithPrefix(logKeySql).WithFields(map[string]interface{}{
+			logKeyId:   e.id,
+			logKeyKind: ctx.Value(ctxKeyKind),
+			logKeyVars: ctx.Value(ctxKeyVars),
+			logKeyTime: time.Since(now).String(),
+		})
 
-		if loc && e.dbo.Closed() == false {
+		if len(e.ns) != 0 {
+			l = l.WithField(logKeyNS, e.ns)
+		}
 
-			// As this is a local transaction then
-			// make sure we reset the transaction
-			// context.
+		if len(e.db) != 0 {
+			l = l.WithField(logKeyDB, e.db)
+		}
 
-			defer e.dbo.Reset()
+		if err != nil {
+			l.WithField(logKeyError, detail(err)).Errorln(stm)
+		} else {
+			l.Debugln(stm)
+		}
 
-			// If there was an error with the query
-			// then clear the queued changes and
-			// return immediately.
+		return res, err
 
-			if err != nil {
-				e.dbo.Cancel()
-				clear(e.id)
-				return
-			}
+	}
+}
 
-			// Otherwise check if this is a read or
-			// a write transaction, and attempt to
-			// Cancel or Commit, returning any errors.
+func (e *executor) begin(ctx context.Context, rw bool) (err error) {
+	if e.dbo.TX == nil {
+		e.dbo = mem.New()
+		e.dbo.TX, err = db.Begin(ctx, rw)
+	}
+	return
+}
 
-			if !trw {
-				if err = e.dbo.Cancel(); err != nil {
-					clear(e.id)
-				} else {
-					clear(e.id)
-				}
-			} else {
-				if err = e.dbo.Commit(); err != nil {
-					clear(e.id)
-				} else {
-					flush(e.id)
-				}
-			}
+// beginAt begins a read-only transaction pinned to the
+// MVCC snapshot at ts, for stale reads and point-in-time
+// debugging.
+func (e *executor) beginAt(ctx context.Context, ts int64) (err error) {
+	if e.dbo.TX == nil {
+		e.dbo = mem.New()
+		e.dbo.TX, err = db.BeginAt(ctx, ts)
+	}
+	return
+}
+
+// beginTX starts the transaction described by a BEGIN
+// statement, choosing between a normal read/write
+// transaction, an explicit read-only one, and a read-only
+// snapshot pinned at a historical or stale timestamp, and
+// records the choice on e.ro/e.ts for operate to enforce.
+func (e *executor) beginTX(ctx context.Context, stm *sql.BeginStatement) (err error) {
+
+	switch {
+
+	case !stm.At.IsZero():
+		if err = e.checkSnapshotHorizon(stm.At); err != nil {
+			return err
+		}
+		e.ro, e.ts = true, stm.At.UnixNano()
+		return e.beginAt(ctx, e.ts)
 
+	case stm.Staleness > 0:
+		at := time.Now().Add(-stm.Staleness)
+		if err = e.checkSnapshotHorizon(at); err != nil {
+			return err
 		}
+		e.ro, e.ts = true, at.UnixNano()
+		return e.beginAt(ctx, e.ts)
+
+	case stm.RO:
+		e.ro, e.ts = true, 0
+		return e.begin(ctx, false)
+
+	default:
+		e.ro, e.ts = false, 0
+		return e.begin(ctx, true)
 
 	}
 
-	return
+}
 
+// checkSnapshotHorizon rejects a requested snapshot time
+// older than gcHorizon allows, so that BEGIN SNAPSHOT AT and
+// BEGIN SNAPSHOT AT MAX STALENESS fail with a clear error
+// instead of either reading already-reclaimed MVCC versions
+// or quietly falling back to the current time.
+func (e *executor) checkSnapshotHorizon(at time.Time) error {
+	if horizon := time.Now().Add(-gcHorizon); at.Before(horizon) {
+		return &StaleSnapshotError{at: at, horizon: horizon}
+	}
+	return nil
 }
 
-func (e *executor) begin(ctx context.Context, rw bool) (err error) {
-	if e.dbo.TX == nil {
-		e.dbo = mem.New()
-		e.dbo.TX, err = db.Begin(ctx, rw)
+// executeInfoForInsights answers `INFO FOR INSIGHTS` by
+// returning a snapshot of the records currently held by
+// the process-wide insights recorder.
+func (e *executor) executeInfoForInsights(ctx context.Context, stm *sql.InfoForInsightsStatement) (out []interface{}, err error) {
+	for _, r := range insights.Default.All() {
+		out = append(out, r)
 	}
 	return
 }
@@ -459,8 +917,16 @@ func (e *executor) cancel(buf []*Response, err error, chn chan<- *Response) (err
 
 	err = e.dbo.Cancel()
 
+	if len(e.stms) > 0 {
+		e.recordInsight(err, false, 0)
+	}
+
+	e.stms = nil
+
 	for _, v := range buf {
 		v.Status = "ERR"
+		v.Code = "ERR"
+		v.Retryable = false
 		v.Result = []interface{}{}
 		v.Detail = "Transaction cancelled"
 		chn <- v
@@ -475,7 +941,7 @@ func (e *executor) cancel(buf []*Response, err error, chn chan<- *Response) (err
 
 }
 
-func (e *executor) commit(buf []*Response, err error, chn chan<- *Response) (error, []*Response) {
+func (e *executor) commit(ctx context.Context, buf []*Response, err error, chn chan<- *Response) (error, []*Response) {
 
 	defer e.dbo.Reset()
 
@@ -489,12 +955,29 @@ func (e *executor) commit(buf []*Response, err error, chn chan<- *Response) (err
 		err = e.dbo.Commit()
 	}
 
+	// If the commit failed because of a conflict with
+	// another concurrent transaction, then replay the
+	// buffered statements against a fresh transaction,
+	// up to a configurable number of attempts.
+
+	var retries int
+
+	if err != nil && isRetryable(err) {
+		buf, retries, err = e.retry(ctx, buf, err)
+	}
+
+	e.recordInsight(err, true, retries)
+
 	for _, v := range buf {
 		if err != nil {
-			v.Status = "ERR"
+			c := classify(err)
+			v.Status = c.Code()
+			v.Code = c.Code()
+			v.Retryable = c.Retryable()
 			v.Result = []interface{}{}
-			v.Detail = "Transaction failed: " + err.Error()
+			v.Detail = detail(err)
 		}
+		v.Retries = retries
 		chn <- v
 	}
 
@@ -503,40 +986,225 @@ func (e *executor) commit(buf []*Response, err error, chn chan<- *Response) (err
 		buf = buf[:len(buf)-1]
 	}
 
+	e.stms = nil
+
 	return err, buf
 
 }
 
-func status(e error) (s string) {
-	switch e.(type) {
-	default:
-		return "OK"
-	case *kvs.DBError:
-		return "ERR_DB"
-	case *kvs.KVError:
-		return "ERR_KV"
-	case *PermsError:
-		return "ERR_PE"
-	case *ExistError:
-		return "ERR_EX"
-	case *FieldError:
-		return "ERR_FD"
-	case *IndexError:
-		return "ERR_IX"
-	case *TimerError:
-		return "ERR_TO"
-	case error:
-		return "ERR"
+// recordInsight hands the just-finished transaction over
+// to the insights subsystem, noting whether the failure
+// (if any) happened at COMMIT or earlier, mid-transaction.
+func (e *executor) recordInsight(err error, commit bool, retries int) {
+
+	var stmtText string
+	if e.errStm != nil {
+		stmtText = fmt.Sprintf("%v", e.errStm)
+	}
+
+	// err is only the error (if any) from the final COMMIT
+	// or CANCEL call. A transaction whose earlier statement
+	// failed can still go on to commit cleanly, in which
+	// case err alone would report it as a plain success.
+	// Fall back to that statement's own error so the
+	// transaction is still recorded, and kept, as a failure.
+	reportErr := err
+	if reportErr == nil {
+		reportErr = e.errErr
+	}
+
+	insights.Default.Record(insights.Record{
+		ID:         e.id,
+		NS:         e.ns,
+		DB:         e.db,
+		Start:      e.txStart,
+		End:        time.Now(),
+		Statements: len(e.stms),
+		Statement:  stmtText,
+		Status:     status(reportErr),
+		Detail:     detail(reportErr),
+		CommitErr:  commit && err != nil,
+		Contention: e.wait,
+		Retries:    retries,
+	})
+
+}
+
+// retry re-runs the statements buffered since the last
+// BEGIN against a brand new transaction, backing off
+// between attempts, and gives up once ctx is done, the
+// configured retry limit is hit, or the failure turns
+// out not to be retryable. err is the failure that caused
+// the retry, and is what is returned if ctx is done before
+// any attempt has been made.
+func (e *executor) retry(ctx context.Context, buf []*Response, err error) ([]*Response, int, error) {
+
+	max := retryMax(e.opts)
+
+	backoff := retryInitialBackoff
+
+	for retries := 1; retries <= max; retries++ {
+
+		select {
+		case <-ctx.Done():
+			return buf, retries - 1, err
+		case <-time.After(backoff):
+			e.wait += backoff
+		}
+
+		backoff = nextBackoff(backoff)
+
+		// Roll back whatever is left of the failed
+		// attempt and begin again with a fresh cache,
+		// so the replay sees a clean MVCC snapshot.
+
+		e.dbo.Cancel()
+		e.dbo = mem.New()
+		e.cache = new(cache)
+
+		// Re-begin the same kind of transaction it was before:
+		// a pinned snapshot keeps seeing the same historical
+		// or stale timestamp, rather than silently reopening
+		// read-write at "now".
+
+		switch {
+		case e.ro && e.ts != 0:
+			err = e.beginAt(ctx, e.ts)
+		case e.ro:
+			err = e.begin(ctx, false)
+		default:
+			err = e.begin(ctx, true)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		replayed := make([]*Response, 0, len(e.stms))
+
+		for _, stm := range e.stms {
+			res, serr := e.operate(ctx, stm)
+			c := classify(serr)
+			rsp := &Response{
+				Status:    c.Code(),
+				Code:      c.Code(),
+				Retryable: c.Retryable(),
+				Detail:    detail(serr),
+				Result:    append([]interface{}{}, res...),
+			}
+			// A RETURN statement replaces the buffered
+			// responses with its own result, same as conduct
+			// does for the non-retried path, so a retried
+			// transaction reports the same response shape as
+			// one that succeeded first try.
+			switch stm.(type) {
+			case *sql.ReturnStatement:
+				replayed = groupd(replayed, rsp)
+			default:
+				replayed = append(replayed, rsp)
+			}
+			if serr != nil {
+				err = serr
+				break
+			}
+		}
+
+		buf = replayed
+
+		if err == nil {
+			err = e.dbo.Commit()
+		}
+
+		if err == nil || !isRetryable(err) {
+			return buf, retries, err
+		}
+
+	}
+
+	return buf, max, err
+
+}
+
+// executeRetryable runs a single, non-transactional
+// statement, retrying it with capped exponential backoff
+// whenever it fails with a retryable error, such as an
+// MVCC write-write conflict reported by the KV layer.
+func (e *executor) executeRetryable(ctx context.Context, stm sql.Statement) (res []interface{}, retries int, err error) {
+
+	max := retryMax(e.opts)
+
+	backoff := retryInitialBackoff
+
+	for {
+
+		res, err = e.operate(ctx, stm)
+
+		if err == nil || !isRetryable(err) || retries >= max {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+			e.wait += backoff
+		}
+
+		backoff = nextBackoff(backoff)
+
+		retries++
+
 	}
+
+}
+
+// isRetryable reports whether the given error represents
+// a transient condition, such as a concurrent transaction
+// conflict reported by the KV layer, which is worth
+// retrying rather than failing the statement outright.
+func isRetryable(err error) bool {
+	return classify(err).Retryable()
+}
+
+// status returns the legacy short status code for e, kept
+// for clients which still key off the `Status` field. It
+// is now simply the Classified code for e; see Classified
+// for the richer {Code, Retryable, HTTPStatus} taxonomy.
+func status(e error) (s string) {
+	return classify(e).Code()
 }
 
 func detail(e error) (s string) {
-	switch err := e.(type) {
-	default:
+	if e == nil {
 		return
-	case error:
-		return err.Error()
 	}
+	return e.Error()
+}
+
+// ReadOnlyError occurs when a write statement is attempted
+// inside a read-only or historical snapshot transaction,
+// such as one started with BEGIN READONLY or BEGIN
+// SNAPSHOT AT.
+type ReadOnlyError struct {
+	stm sql.Statement
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("Can not execute a write statement inside a read-only transaction: %v", e.stm)
+}
+
+// StaleSnapshotError occurs when a BEGIN SNAPSHOT AT or
+// BEGIN SNAPSHOT AT MAX STALENESS statement requests a
+// version time older than gcHorizon, and so may already
+// have been reclaimed by the storage layer's garbage
+// collector.
+type StaleSnapshotError struct {
+	at      time.Time
+	horizon time.Time
+}
+
+func (e *StaleSnapshotError) Error() string {
+	return fmt.Sprintf("Can not start a snapshot transaction at %s: it is older than the garbage collection horizon of %s", e.at.Format(time.RFC3339), e.horizon.Format(time.RFC3339))
 }
 
 func groupd(buf []*Response, rsp *Response) []*Response {
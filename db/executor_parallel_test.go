@@ -0,0 +1,87 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/abcum/surreal/sql"
+)
+
+// fakeWriteStatement is a minimal sql.WriteableStatement used
+// to exercise groupParallel's write-boundary handling, since
+// this tree carries no concrete write statement types.
+type fakeWriteStatement struct{}
+
+func (fakeWriteStatement) Writeable() bool { return true }
+
+type fakeReadStatement struct{}
+
+func TestGroupParallelNonParallelGivesOneStatementPerGroup(t *testing.T) {
+
+	stms := []sql.Statement{&fakeReadStatement{}, &fakeReadStatement{}, &fakeReadStatement{}}
+
+	groups := groupParallel(stms, false)
+
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups))
+	}
+
+	for _, g := range groups {
+		if len(g) != 1 {
+			t.Errorf("expected each group to hold a single statement, got %d", len(g))
+		}
+	}
+
+}
+
+func TestGroupParallelGroupsConsecutiveReads(t *testing.T) {
+
+	stms := []sql.Statement{&fakeReadStatement{}, &fakeReadStatement{}, &fakeReadStatement{}}
+
+	groups := groupParallel(stms, true)
+
+	if len(groups) != 1 || len(groups[0]) != 3 {
+		t.Fatalf("expected a single group of 3 reads, got %v", groups)
+	}
+
+}
+
+func TestGroupParallelBreaksOnWritesAndTxControl(t *testing.T) {
+
+	stms := []sql.Statement{
+		&fakeReadStatement{},
+		&fakeReadStatement{},
+		&fakeWriteStatement{},
+		&fakeReadStatement{},
+		&sql.ReturnStatement{},
+		&fakeReadStatement{},
+	}
+
+	groups := groupParallel(stms, true)
+
+	want := [][]int{{0, 1}, {2}, {3}, {4}, {5}}
+
+	if len(groups) != len(want) {
+		t.Fatalf("expected %d groups, got %d: %v", len(want), len(groups), groups)
+	}
+
+	for i, g := range groups {
+		if len(g) != len(want[i]) {
+			t.Errorf("group %d: expected %d statements, got %d", i, len(want[i]), len(g))
+		}
+	}
+
+}
@@ -0,0 +1,128 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abcum/surreal/insights"
+	"github.com/abcum/surreal/sql"
+)
+
+func TestRetryMax(t *testing.T) {
+
+	if max := retryMax(nil); max != retryDefaultMax {
+		t.Errorf("expected %d, got %d", retryDefaultMax, max)
+	}
+
+	if max := retryMax(&options{}); max != retryDefaultMax {
+		t.Errorf("expected %d, got %d", retryDefaultMax, max)
+	}
+
+	if max := retryMax(&options{retry: 9}); max != 9 {
+		t.Errorf("expected 9, got %d", max)
+	}
+
+}
+
+func TestNextBackoff(t *testing.T) {
+
+	cases := []struct {
+		in, out time.Duration
+	}{
+		{retryInitialBackoff, 10 * time.Millisecond},
+		{40 * time.Millisecond, retryMaxBackoff},
+		{retryMaxBackoff, retryMaxBackoff},
+	}
+
+	for _, c := range cases {
+		if got := nextBackoff(c.in); got != c.out {
+			t.Errorf("nextBackoff(%s): expected %s, got %s", c.in, c.out, got)
+		}
+	}
+
+}
+
+func TestExecuteOptRetry(t *testing.T) {
+
+	e := &executor{opts: newOptions()}
+
+	stm := &sql.OptStatement{Name: "RETRY", What: int64(3)}
+
+	if _, err := e.executeOpt(context.Background(), stm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if e.opts.retry != 3 {
+		t.Errorf("expected retry option to be set to 3, got %d", e.opts.retry)
+	}
+
+}
+
+func TestRecordInsightReportsMidTransactionFailure(t *testing.T) {
+
+	e := &executor{
+		id:      "TestRecordInsightReportsMidTransactionFailure",
+		txStart: time.Now(),
+		errStm:  &sql.CommitStatement{},
+		errErr:  errors.New("mid-transaction boom"),
+	}
+
+	// The transaction itself went on to commit cleanly, so
+	// the final error passed to recordInsight is nil.
+	e.recordInsight(nil, true, 0)
+
+	all := insights.Default.All()
+	if len(all) == 0 {
+		t.Fatal("expected the transaction to be recorded despite a clean commit")
+	}
+
+	last := all[len(all)-1]
+
+	if last.ID != e.id {
+		t.Fatalf("expected the last record to be ours, got %+v", last)
+	}
+
+	if last.Status == "OK" {
+		t.Error("a transaction with a mid-transaction failure should not be reported as OK")
+	}
+
+}
+
+func TestCheckSnapshotHorizon(t *testing.T) {
+
+	e := &executor{}
+
+	if err := e.checkSnapshotHorizon(time.Now()); err != nil {
+		t.Errorf("unexpected error for the current time: %v", err)
+	}
+
+	if err := e.checkSnapshotHorizon(time.Now().Add(-gcHorizon / 2)); err != nil {
+		t.Errorf("unexpected error for a snapshot inside the horizon: %v", err)
+	}
+
+	err := e.checkSnapshotHorizon(time.Now().Add(-2 * gcHorizon))
+	if err == nil {
+		t.Fatal("expected an error for a snapshot older than the horizon")
+	}
+
+	if _, ok := err.(*StaleSnapshotError); !ok {
+		t.Errorf("expected a *StaleSnapshotError, got %T", err)
+	}
+
+}
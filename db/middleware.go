@@ -0,0 +1,70 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"context"
+	"sync"
+
+	"github.com/abcum/surreal/sql"
+)
+
+// StatementHandler executes a single statement and
+// returns its result set, or an error. It is the shape
+// that both the executor's own dispatch method, and every
+// registered Middleware, conform to.
+type StatementHandler func(ctx context.Context, stm sql.Statement) ([]interface{}, error)
+
+// Middleware wraps a StatementHandler with additional
+// cross-cutting behaviour - auditing, rate limiting, query
+// rewriting, tracing - without needing to touch the type
+// switch inside the executor.
+type Middleware func(next StatementHandler) StatementHandler
+
+var (
+	middlewaresMu sync.Mutex
+	middlewares   []Middleware
+)
+
+// Use registers a Middleware to run around every
+// statement dispatched by the executor. Middlewares are
+// applied in registration order, so the first Middleware
+// registered is the outermost, and sees the statement
+// before any of the others.
+func Use(mw Middleware) {
+	middlewaresMu.Lock()
+	defer middlewaresMu.Unlock()
+	middlewares = append(middlewares, mw)
+}
+
+// globalMiddlewares returns a snapshot of the currently
+// registered middlewares, safe to range over without
+// holding middlewaresMu.
+func globalMiddlewares() []Middleware {
+	middlewaresMu.Lock()
+	defer middlewaresMu.Unlock()
+	return append([]Middleware(nil), middlewares...)
+}
+
+// chain builds the StatementHandler that operate invokes
+// for a statement, wrapping next with every middleware
+// registered via Use, outermost first.
+func chain(next StatementHandler) StatementHandler {
+	mws := globalMiddlewares()
+	for i := len(mws) - 1; i >= 0; i-- {
+		next = mws[i](next)
+	}
+	return next
+}
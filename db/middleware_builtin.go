@@ -0,0 +1,136 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/abcum/surreal/sql"
+)
+
+// Span is the minimal interface a tracing backend needs
+// to implement for TracingMiddleware to attach per-
+// statement spans. It intentionally mirrors the shape of
+// an OpenTelemetry span so that a thin adapter is all that
+// is needed to plug in a real exporter.
+type Span interface {
+	SetAttr(key string, val interface{})
+	End()
+}
+
+// Tracer starts a new Span for the statement about to be
+// dispatched, deriving it from any span already present
+// on ctx.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// TracingMiddleware starts one Span per statement via t,
+// and attaches the statement kind and bind variables as
+// attributes, plus the error text when the statement
+// fails. The current namespace and database are not
+// attached.
+func TracingMiddleware(t Tracer) Middleware {
+	return func(next StatementHandler) StatementHandler {
+		return func(ctx context.Context, stm sql.Statement) ([]interface{}, error) {
+
+			ctx, span := t.Start(ctx, fmt.Sprintf("%T", stm))
+			defer span.End()
+
+			span.SetAttr("kind", ctx.Value(ctxKeyKind))
+			span.SetAttr("vars", ctx.Value(ctxKeyVars))
+
+			res, err := next(ctx, stm)
+
+			if err != nil {
+				span.SetAttr("error", err.Error())
+			}
+
+			return res, err
+
+		}
+	}
+}
+
+// loginLimiter bounds the number of statements which may
+// be running concurrently for a single login, blocking
+// additional ones until a slot frees up.
+type loginLimiter struct {
+	max  int
+	mu   sync.Mutex
+	cond *sync.Cond
+	cur  map[string]int
+}
+
+// NewLoginConcurrencyLimiter returns a Middleware which
+// allows at most max statements to run concurrently for
+// any one login, queuing any beyond that until a slot
+// becomes available.
+func NewLoginConcurrencyLimiter(max int) Middleware {
+
+	l := &loginLimiter{max: max, cur: make(map[string]int)}
+	l.cond = sync.NewCond(&l.mu)
+
+	return func(next StatementHandler) StatementHandler {
+		return func(ctx context.Context, stm sql.Statement) ([]interface{}, error) {
+
+			login := loginOf(ctx)
+
+			l.mu.Lock()
+			for l.cur[login] >= l.max {
+				l.cond.Wait()
+			}
+			l.cur[login]++
+			l.mu.Unlock()
+
+			defer func() {
+				l.mu.Lock()
+				l.cur[login]--
+				l.cond.Broadcast()
+				l.mu.Unlock()
+			}()
+
+			return next(ctx, stm)
+
+		}
+	}
+}
+
+// loginCtxKey is the context key under which the
+// authenticated principal's identity is stored by
+// WithLogin.
+type loginCtxKey struct{}
+
+// WithLogin attaches the identity of the authenticated
+// login running the current request to ctx, so that
+// NewLoginConcurrencyLimiter can bucket by principal. This
+// is expected to be called once, where a connection's
+// login is first established.
+func WithLogin(ctx context.Context, login string) context.Context {
+	return context.WithValue(ctx, loginCtxKey{}, login)
+}
+
+// loginOf extracts the identity of the logged-in
+// principal running the current statement, falling back
+// to the empty string (a single shared bucket) when no
+// login information has been attached to ctx.
+func loginOf(ctx context.Context) string {
+	if login, ok := ctx.Value(loginCtxKey{}).(string); ok {
+		return login
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abcum/surreal/sql"
+)
+
+// indexOf returns the position of s in order, or -1.
+func indexOf(order []string, s string) int {
+	for i, v := range order {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestChainRunsMiddlewaresInRegistrationOrder(t *testing.T) {
+
+	var order []string
+
+	marker := func(name string) Middleware {
+		return func(next StatementHandler) StatementHandler {
+			return func(ctx context.Context, stm sql.Statement) ([]interface{}, error) {
+				order = append(order, "before:"+name)
+				res, err := next(ctx, stm)
+				order = append(order, "after:"+name)
+				return res, err
+			}
+		}
+	}
+
+	Use(marker("outer-TestChainRunsMiddlewaresInRegistrationOrder"))
+	Use(marker("inner-TestChainRunsMiddlewaresInRegistrationOrder"))
+
+	handler := chain(func(ctx context.Context, stm sql.Statement) ([]interface{}, error) {
+		order = append(order, "dispatch")
+		return nil, nil
+	})
+
+	if _, err := handler(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	before1 := indexOf(order, "before:outer-TestChainRunsMiddlewaresInRegistrationOrder")
+	before2 := indexOf(order, "before:inner-TestChainRunsMiddlewaresInRegistrationOrder")
+	dispatch := indexOf(order, "dispatch")
+	after2 := indexOf(order, "after:inner-TestChainRunsMiddlewaresInRegistrationOrder")
+	after1 := indexOf(order, "after:outer-TestChainRunsMiddlewaresInRegistrationOrder")
+
+	if !(before1 < before2 && before2 < dispatch && dispatch < after2 && after2 < after1) {
+		t.Fatalf("expected the first-registered middleware to be outermost, got order: %v", order)
+	}
+
+}
+
+func TestLoginConcurrencyLimiterBlocksAndWakes(t *testing.T) {
+
+	mw := NewLoginConcurrencyLimiter(1)
+
+	release := make(chan struct{})
+	firstRunning := make(chan struct{})
+
+	first := mw(func(ctx context.Context, stm sql.Statement) ([]interface{}, error) {
+		close(firstRunning)
+		<-release
+		return nil, nil
+	})
+
+	secondStarted := make(chan struct{})
+	second := mw(func(ctx context.Context, stm sql.Statement) ([]interface{}, error) {
+		close(secondStarted)
+		return nil, nil
+	})
+
+	ctx := WithLogin(context.Background(), "TestLoginConcurrencyLimiterBlocksAndWakes")
+
+	go first(ctx, nil)
+	<-firstRunning
+
+	go second(ctx, nil)
+
+	select {
+	case <-secondStarted:
+		t.Fatal("second statement should be blocked while the first holds the only slot")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case <-secondStarted:
+	case <-time.After(time.Second):
+		t.Fatal("second statement should run once the first releases its slot")
+	}
+
+}
+
+func TestLoginConcurrencyLimiterDoesNotBlockDifferentLogins(t *testing.T) {
+
+	mw := NewLoginConcurrencyLimiter(1)
+
+	release := make(chan struct{})
+	firstRunning := make(chan struct{})
+
+	first := mw(func(ctx context.Context, stm sql.Statement) ([]interface{}, error) {
+		close(firstRunning)
+		<-release
+		return nil, nil
+	})
+
+	secondDone := make(chan struct{})
+	second := mw(func(ctx context.Context, stm sql.Statement) ([]interface{}, error) {
+		return nil, nil
+	})
+
+	ctxA := WithLogin(context.Background(), "alice-TestLoginConcurrencyLimiterDoesNotBlockDifferentLogins")
+	ctxB := WithLogin(context.Background(), "bob-TestLoginConcurrencyLimiterDoesNotBlockDifferentLogins")
+
+	go first(ctxA, nil)
+	<-firstRunning
+
+	go func() {
+		second(ctxB, nil)
+		close(secondDone)
+	}()
+
+	select {
+	case <-secondDone:
+	case <-time.After(time.Second):
+		t.Fatal("a different login should not be blocked by alice's held slot")
+	}
+
+	close(release)
+
+}
@@ -0,0 +1,67 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/abcum/surreal/insights"
+	"github.com/abcum/surreal/sql"
+)
+
+// options holds the per-connection runtime configuration
+// that OPTION statements can alter.
+type options struct {
+	// retry is the maximum number of times a retryable
+	// commit failure is retried before giving up, or 0
+	// to use retryDefaultMax.
+	retry int
+}
+
+func newOptions() *options {
+	return &options{}
+}
+
+// executeOpt applies a single `OPTION name = what` statement
+// to the executor's options, such as `OPTION RETRY = 5`.
+func (e *executor) executeOpt(ctx context.Context, stm *sql.OptStatement) (out []interface{}, err error) {
+
+	switch stm.Name {
+
+	case "RETRY":
+		if n, ok := stm.What.(int64); ok && n > 0 {
+			e.opts.retry = int(n)
+		}
+
+	// INSIGHTS LATENCY and INSIGHTS RETRIES adjust the
+	// process-wide thresholds that decide which transactions
+	// are worth recording in the insights subsystem.
+
+	case "INSIGHTS LATENCY":
+		if d, ok := stm.What.(time.Duration); ok {
+			insights.Default.Latency = d
+		}
+
+	case "INSIGHTS RETRIES":
+		if n, ok := stm.What.(int64); ok {
+			insights.Default.Retries = int(n)
+		}
+
+	}
+
+	return
+
+}
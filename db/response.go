@@ -0,0 +1,36 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+// Response is the result of running a single sql statement,
+// as sent back to the caller over the executor's channel.
+type Response struct {
+	Time   string        `json:"time"`
+	Status string        `json:"status"`
+	Detail string        `json:"detail,omitempty"`
+	Result []interface{} `json:"result"`
+
+	// Code and Retryable mirror Status as a stable,
+	// client-facing classification (see Classified), so a
+	// driver can decide whether to loop on this response
+	// without pattern-matching Detail.
+	Code      string `json:"code"`
+	Retryable bool   `json:"retryable"`
+
+	// Retries is the number of times the statement, or the
+	// transaction it belonged to, was re-attempted before
+	// this response was produced.
+	Retries int `json:"retries,omitempty"`
+}
@@ -0,0 +1,141 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package insights records diagnostic information about
+// failed and slow transactions, so that operators can
+// diagnose contention and deadlocks which would otherwise
+// only surface as a generic "Transaction failed" error.
+package insights
+
+import (
+	"sync"
+	"time"
+
+	"github.com/abcum/surreal/log"
+)
+
+// Record is a single diagnostic entry describing a
+// transaction which either failed, or ran slowly enough,
+// or retried enough, to be worth keeping.
+type Record struct {
+	ID         string
+	NS         string
+	DB         string
+	Start      time.Time
+	End        time.Time
+	Statements int
+	Statement  string
+	Status     string
+	Detail     string
+	CommitErr  bool
+	Contention time.Duration
+	Retries    int
+	Stack      string
+}
+
+// Duration returns the wall-clock time the transaction
+// took to run, from its first statement to its last.
+func (r *Record) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// Recorder is a fixed-size ring buffer of the most recent
+// insight Records, along with the thresholds that decide
+// which transactions are worth keeping.
+type Recorder struct {
+	mu      sync.Mutex
+	buf     []Record
+	next    int
+	full    bool
+	Latency time.Duration
+	Retries int
+}
+
+// New creates a Recorder which keeps at most size
+// records, evicting the oldest entry once full.
+func New(size int) *Recorder {
+	return &Recorder{
+		buf:     make([]Record, size),
+		Latency: 500 * time.Millisecond,
+	}
+}
+
+// Default is the process-wide recorder used by the db
+// package, and queried by the `INFO FOR INSIGHTS` statement.
+var Default = New(256)
+
+// Worth reports whether r crosses one of the configured
+// thresholds, and should therefore be kept and logged.
+func (c *Recorder) Worth(r *Record) bool {
+	return r.Status != "OK" || r.Duration() >= c.Latency || r.Retries > c.Retries
+}
+
+// Record appends r to the ring buffer, evicting the
+// oldest entry once the buffer is full, and writes a
+// structured log line so that operators can alert on it.
+func (c *Recorder) Record(r Record) {
+
+	if !c.Worth(&r) {
+		return
+	}
+
+	c.mu.Lock()
+	c.buf[c.next] = r
+	c.next = (c.next + 1) % len(c.buf)
+	if c.next == 0 {
+		c.full = true
+	}
+	c.mu.Unlock()
+
+	fields := map[string]interface{}{
+		"id":         r.ID,
+		"ns":         r.NS,
+		"db":         r.DB,
+		"statements": r.Statements,
+		"status":     r.Status,
+		"commit":     r.CommitErr,
+		"contention": r.Contention.String(),
+		"retries":    r.Retries,
+		"duration":   r.Duration().String(),
+	}
+
+	l := log.WithPrefix("insights").WithFields(fields)
+
+	if r.Status != "OK" {
+		l.Errorln(r.Statement, r.Detail)
+	} else {
+		l.Warnln(r.Statement)
+	}
+
+}
+
+// All returns a snapshot of the records currently held,
+// ordered oldest first.
+func (c *Recorder) All() []Record {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.full {
+		out := make([]Record, c.next)
+		copy(out, c.buf[:c.next])
+		return out
+	}
+
+	out := make([]Record, len(c.buf))
+	copy(out, c.buf[c.next:])
+	copy(out[len(c.buf)-c.next:], c.buf[:c.next])
+	return out
+
+}
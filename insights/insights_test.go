@@ -0,0 +1,78 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package insights
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWorth(t *testing.T) {
+
+	r := New(4)
+
+	ok := Record{Status: "OK", Start: time.Unix(0, 0), End: time.Unix(0, 0)}
+	if r.Worth(&ok) {
+		t.Error("a fast, successful transaction should not be worth recording")
+	}
+
+	failed := Record{Status: "ERR", Start: time.Unix(0, 0), End: time.Unix(0, 0)}
+	if !r.Worth(&failed) {
+		t.Error("a failed transaction should always be worth recording")
+	}
+
+	slow := Record{Status: "OK", Start: time.Unix(0, 0), End: time.Unix(0, 0).Add(r.Latency)}
+	if !r.Worth(&slow) {
+		t.Error("a transaction at or past the latency threshold should be worth recording")
+	}
+
+	retried := Record{Status: "OK", Start: time.Unix(0, 0), End: time.Unix(0, 0), Retries: r.Retries + 1}
+	if !r.Worth(&retried) {
+		t.Error("a transaction past the retry threshold should be worth recording")
+	}
+
+}
+
+func TestRecordRingBuffer(t *testing.T) {
+
+	r := New(2)
+
+	r.Record(Record{Status: "ERR", Detail: "first"})
+	r.Record(Record{Status: "ERR", Detail: "second"})
+	r.Record(Record{Status: "ERR", Detail: "third"})
+
+	all := r.All()
+
+	if len(all) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(all))
+	}
+
+	if all[0].Detail != "second" || all[1].Detail != "third" {
+		t.Errorf("expected the oldest record to have been evicted, got %+v", all)
+	}
+
+}
+
+func TestRecordSkipsUnworthy(t *testing.T) {
+
+	r := New(4)
+
+	r.Record(Record{Status: "OK", Start: time.Unix(0, 0), End: time.Unix(0, 0)})
+
+	if len(r.All()) != 0 {
+		t.Error("a fast, successful transaction should not be kept")
+	}
+
+}
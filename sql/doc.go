@@ -0,0 +1,30 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sql defines the abstract syntax tree that the db
+// package executes against a running transaction.
+//
+// The types in this package - Query, BeginStatement,
+// OptStatement, InfoForInsightsStatement and the rest - are
+// the target of that tree, not its source: a lexer and parser
+// somewhere upstream are responsible for turning query text
+// such as `BEGIN SNAPSHOT AT ...`, `OPTION RETRY = 5` or
+// `SELECT ... PARALLEL` into these structs. That front end is
+// not part of this package, so new fields added here (for
+// example to support a new clause) only take effect for
+// callers that build the AST directly; wiring the matching
+// keyword(s) into the lexer/parser is a separate piece of
+// work and should be tracked and landed alongside any change
+// here that is meant to be reachable from SQL text.
+package sql
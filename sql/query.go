@@ -0,0 +1,28 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+// Query represents a parsed and parameterised SQL query,
+// consisting of one or more statements to be run in order
+// against the database.
+type Query struct {
+	Statements []Statement
+
+	// Parallel marks the query as having been parsed with
+	// the PARALLEL modifier, allowing the executor to
+	// dispatch consecutive, non-conflicting read-only
+	// statements concurrently instead of one at a time.
+	Parallel bool
+}
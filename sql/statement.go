@@ -0,0 +1,35 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import "time"
+
+// Statement is implemented by every parsed SQL statement
+// that an executor can dispatch.
+type Statement interface{}
+
+// WriteableStatement is implemented by statements which
+// mutate data, so the executor can tell a read from a
+// write without a full type switch.
+type WriteableStatement interface {
+	Writeable() bool
+}
+
+// KillableStatement is implemented by statements which
+// carry a maximum running duration, after which the
+// executor cancels them with a TimerError.
+type KillableStatement interface {
+	Duration() time.Duration
+}
@@ -0,0 +1,49 @@
+// Copyright © 2016 Abcum Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import "time"
+
+// BeginStatement starts a new explicit transaction. By
+// default it opens a normal read/write transaction; RO, At
+// and Staleness select one of the read-only forms instead:
+//
+//	BEGIN READONLY;
+//	BEGIN SNAPSHOT AT '2024-01-01T00:00:00Z';
+//	BEGIN SNAPSHOT AT MAX STALENESS 10s;
+type BeginStatement struct {
+	// RO marks an explicit read-only transaction, pinned to
+	// the current time, with no historical snapshot.
+	RO bool
+
+	// At pins the transaction to the MVCC snapshot nearest
+	// this historical timestamp, for BEGIN SNAPSHOT AT.
+	At time.Time
+
+	// Staleness pins the transaction to a snapshot this far
+	// in the past, for BEGIN SNAPSHOT AT MAX STALENESS.
+	Staleness time.Duration
+}
+
+// CancelStatement rolls back the current explicit
+// transaction.
+type CancelStatement struct{}
+
+// CommitStatement commits the current explicit transaction.
+type CommitStatement struct{}
+
+// ReturnStatement marks the value that should be returned
+// in place of the buffered responses for the transaction.
+type ReturnStatement struct{}